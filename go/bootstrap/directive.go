@@ -0,0 +1,119 @@
+package bootstrap
+
+import (
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"axlab.dev/bit/util"
+)
+
+// Action is the action-line directive parsed from the first non-blank line
+// of a script test.
+type Action string
+
+const (
+	ActionRun        Action = "run"
+	ActionSkip       Action = "skip"
+	ActionCompile    Action = "compile"
+	ActionBuild      Action = "build"
+	ActionErrorCheck Action = "errorcheck"
+)
+
+// Directive is the parsed action-line for a script test.
+type Directive struct {
+	Action Action
+
+	// Constraint is an optional build-tag-like expression such as
+	// "windows" or "!race", checked against runtime.GOOS and Tags.
+	Constraint string
+
+	// Reason is free text following the action, e.g. the explanation on a
+	// `// skip: known flaky parser bug` line.
+	Reason string
+
+	// Timeout overrides DefaultTimeout when the directive carries a
+	// `timeout:30s` token, e.g. `// run timeout:30s`.
+	Timeout time.Duration
+
+	// Format picks the fixture format ("" or "json") a missing `.out`
+	// expectation is generated in under -update, from a `format:json`
+	// token, e.g. `// run format:json`.
+	Format string
+}
+
+// Tags holds the build tags checked against a directive's Constraint. It is
+// populated from RunOptions.Tags by RunAllScriptTests.
+var Tags = map[string]bool{}
+
+var (
+	directiveLine = regexp.MustCompile(`^//\s*(\w+)(?::(\S+))?\s*(.*)$`)
+	timeoutToken  = regexp.MustCompile(`\btimeout:(\S+)`)
+	formatToken   = regexp.MustCompile(`\bformat:(\S+)`)
+)
+
+// parseDirective reads the first non-blank line of a script's source and
+// parses it as an action-line directive. A missing or unrecognized
+// directive defaults to ActionRun, the historical "execute and diff
+// stdout" behavior.
+func parseDirective(source string) Directive {
+	for _, line := range util.Lines(source) {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if m := directiveLine.FindStringSubmatch(line); m != nil {
+			switch action := Action(m[1]); action {
+			case ActionRun, ActionSkip, ActionCompile, ActionBuild, ActionErrorCheck:
+				// m[2] only captures a colon-attached constraint like
+				// "run:windows"; "skip: reason" leaves its colon in m[3].
+				reason := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(m[3]), ":"))
+
+				var timeout time.Duration
+				if tm := timeoutToken.FindStringSubmatch(reason); tm != nil {
+					if d, err := time.ParseDuration(tm[1]); err == nil {
+						timeout = d
+					}
+					reason = strings.TrimSpace(timeoutToken.ReplaceAllString(reason, ""))
+				}
+
+				var format string
+				if fm := formatToken.FindStringSubmatch(reason); fm != nil {
+					format = fm[1]
+					reason = strings.TrimSpace(formatToken.ReplaceAllString(reason, ""))
+				}
+
+				return Directive{Action: action, Constraint: m[2], Reason: reason, Timeout: timeout, Format: format}
+
+			case "timeout":
+				// A standalone `// timeout:30s` line (no action token)
+				// overrides DefaultTimeout for an otherwise plain run.
+				var timeout time.Duration
+				if d, err := time.ParseDuration(m[2]); err == nil {
+					timeout = d
+				}
+				return Directive{Action: ActionRun, Reason: strings.TrimSpace(m[3]), Timeout: timeout}
+			}
+		}
+		break
+	}
+	return Directive{Action: ActionRun}
+}
+
+// directiveApplies reports whether a directive's build-tag-like constraint
+// (e.g. "windows" or "!race") is satisfied for the current GOOS and tags.
+func directiveApplies(constraint string, tags map[string]bool) bool {
+	if constraint == "" {
+		return true
+	}
+
+	negate := strings.HasPrefix(constraint, "!")
+	name := strings.TrimPrefix(constraint, "!")
+	has := name == runtime.GOOS || tags[name]
+	if negate {
+		return !has
+	}
+	return has
+}
@@ -0,0 +1,77 @@
+package bootstrap
+
+import "testing"
+
+func TestParseDirective(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   Directive
+	}{
+		{"empty", "", Directive{Action: ActionRun}},
+		{"no directive", "let x = 1\n", Directive{Action: ActionRun}},
+		{"run", "// run\n", Directive{Action: ActionRun}},
+		{"skip with reason", "// skip: known flaky parser bug\n", Directive{Action: ActionSkip, Reason: "known flaky parser bug"}},
+		{"compile", "// compile\n", Directive{Action: ActionCompile}},
+		{"build", "// build\n", Directive{Action: ActionBuild}},
+		{"errorcheck", "// errorcheck\n", Directive{Action: ActionErrorCheck}},
+		{"constraint", "// run:windows\n", Directive{Action: ActionRun, Constraint: "windows"}},
+		{"unrecognized action falls back to run", "// frobnicate\n", Directive{Action: ActionRun}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := parseDirective(test.source)
+			if got != test.want {
+				t.Errorf("parseDirective(%q) = %+v, want %+v", test.source, got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseDirectiveTrailingTokens(t *testing.T) {
+	got := parseDirective("// run timeout:30s format:json trailing reason\n")
+	if got.Action != ActionRun {
+		t.Errorf("Action = %v, want %v", got.Action, ActionRun)
+	}
+	if got.Timeout.String() != "30s" {
+		t.Errorf("Timeout = %v, want 30s", got.Timeout)
+	}
+	if got.Format != "json" {
+		t.Errorf("Format = %q, want %q", got.Format, "json")
+	}
+	if got.Reason != "trailing reason" {
+		t.Errorf("Reason = %q, want %q", got.Reason, "trailing reason")
+	}
+}
+
+func TestParseDirectiveStandaloneTimeout(t *testing.T) {
+	got := parseDirective("// timeout:5s\n")
+	if got.Action != ActionRun {
+		t.Errorf("Action = %v, want %v", got.Action, ActionRun)
+	}
+	if got.Timeout.String() != "5s" {
+		t.Errorf("Timeout = %v, want 5s", got.Timeout)
+	}
+}
+
+func TestDirectiveApplies(t *testing.T) {
+	tags := map[string]bool{"race": true}
+
+	tests := []struct {
+		constraint string
+		want       bool
+	}{
+		{"", true},
+		{"race", true},
+		{"!race", false},
+		{"nonexistent", false},
+		{"!nonexistent", true},
+	}
+
+	for _, test := range tests {
+		if got := directiveApplies(test.constraint, tags); got != test.want {
+			t.Errorf("directiveApplies(%q) = %v, want %v", test.constraint, got, test.want)
+		}
+	}
+}
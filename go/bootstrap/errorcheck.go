@@ -0,0 +1,200 @@
+package bootstrap
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"axlab.dev/bit/util"
+)
+
+// errorAnnotation is a single `// ERROR "pattern"` comment (or its
+// `ERRORNEXT`/`GC_ERROR` variants) attached to a line of script source.
+type errorAnnotation struct {
+	Line     int
+	Patterns []*regexp.Regexp
+	Raw      []string // pattern text, index-aligned with Patterns
+	Invalid  []string // pattern text that failed to compile as a regexp
+}
+
+// diagnostic is a single compiler error message parsed from stderr.
+type diagnostic struct {
+	File string
+	Line int
+	Col  int
+	Msg  string
+}
+
+var (
+	errorCommentRe = regexp.MustCompile(`//\s*(ERROR|ERRORNEXT|GC_ERROR)\b(.*)$`)
+	errorPatternRe = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"`)
+	diagnosticRe   = regexp.MustCompile(`^(.+?):(\d+):(\d+):\s*(.*)$`)
+)
+
+// parseErrorAnnotations scans a script's source for `// ERROR "pattern"`
+// style comments and returns one entry per annotated line. `ERRORNEXT`
+// attaches its patterns to the following line, for diagnostics on lines
+// that can't carry a trailing comment of their own.
+func parseErrorAnnotations(source string) []errorAnnotation {
+	var out []errorAnnotation
+	for i, line := range util.Lines(source) {
+		m := errorCommentRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+
+		lineNo := i + 1
+		if m[1] == "ERRORNEXT" {
+			lineNo++
+		}
+
+		var raw []string
+		var invalid []string
+		var patterns []*regexp.Regexp
+		for _, pm := range errorPatternRe.FindAllStringSubmatch(m[2], -1) {
+			if re, err := regexp.Compile(pm[1]); err == nil {
+				raw = append(raw, pm[1])
+				patterns = append(patterns, re)
+			} else {
+				invalid = append(invalid, pm[1])
+			}
+		}
+
+		out = append(out, errorAnnotation{Line: lineNo, Patterns: patterns, Raw: raw, Invalid: invalid})
+	}
+	return out
+}
+
+// parseDiagnostics parses a compiler's stderr into (file, line, col, msg)
+// tuples, one per `file:line:col: message` line it emits.
+func parseDiagnostics(stderr string) []diagnostic {
+	var out []diagnostic
+	for _, line := range util.Lines(stderr) {
+		m := diagnosticRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		lineNo, _ := strconv.Atoi(m[2])
+		col, _ := strconv.Atoi(m[3])
+		out = append(out, diagnostic{File: m[1], Line: lineNo, Col: col, Msg: m[4]})
+	}
+	return out
+}
+
+// checkErrorCheckResult implements the `errorcheck` action: instead of
+// diffing stdout, it requires every `// ERROR` pattern to match some
+// diagnostic on its line, and every diagnostic to be matched by some
+// annotation on its line.
+func (test *ScriptTest) checkErrorCheckResult() {
+	annotations := parseErrorAnnotations(util.ReadText(test.File))
+	diagnostics := parseDiagnostics(test.StdErr)
+
+	byLine := map[int][]errorAnnotation{}
+	for _, ann := range annotations {
+		byLine[ann.Line] = append(byLine[ann.Line], ann)
+	}
+	diagsByLine := map[int][]diagnostic{}
+	for _, d := range diagnostics {
+		diagsByLine[d.Line] = append(diagsByLine[d.Line], d)
+	}
+
+	var problems []string
+	for _, ann := range annotations {
+		for _, bad := range ann.Invalid {
+			problems = append(problems, fmt.Sprintf("line %d: invalid pattern %q", ann.Line, bad))
+		}
+		for i, pattern := range ann.Patterns {
+			matched := false
+			for _, d := range diagsByLine[ann.Line] {
+				matched = matched || pattern.MatchString(d.Msg)
+			}
+			if !matched {
+				problems = append(problems, fmt.Sprintf("line %d: no diagnostic matched %q", ann.Line, ann.Raw[i]))
+			}
+		}
+	}
+	for _, d := range diagnostics {
+		matched := false
+		for _, ann := range byLine[d.Line] {
+			for _, pattern := range ann.Patterns {
+				matched = matched || pattern.MatchString(d.Msg)
+			}
+		}
+		if !matched {
+			problems = append(problems, fmt.Sprintf("line %d: unexpected diagnostic %q", d.Line, d.Msg))
+		}
+	}
+
+	test.Success = len(problems) == 0
+	if test.Success {
+		test.Error = nil
+		return
+	}
+
+	if *flagUpdateErrors {
+		util.WriteText(test.File, rewriteErrorAnnotations(util.ReadText(test.File), diagnostics))
+		test.Success = true
+		test.Error = nil
+		test.Updated = true
+		return
+	}
+
+	test.Error = fmt.Errorf("%s", strings.Join(problems, "; "))
+}
+
+// rewriteErrorAnnotations replaces or inserts `// ERROR "..."` comments on
+// every line that has an observed diagnostic, quoting the message with
+// regexp.QuoteMeta so it matches literally next run, and removes the
+// `// ERROR` comment from every other line. Lines carrying an `ERRORNEXT`
+// annotation are left untouched, and lines an `ERRORNEXT` on the line above
+// targets get no annotation of their own, since their diagnostic is already
+// covered.
+func rewriteErrorAnnotations(source string, diagnostics []diagnostic) string {
+	lines := util.Lines(source)
+
+	msgsByLine := map[int][]string{}
+	for _, d := range diagnostics {
+		msgsByLine[d.Line] = append(msgsByLine[d.Line], d.Msg)
+	}
+
+	errorNextTargets := map[int]bool{}
+	for i, line := range lines {
+		m := errorCommentRe.FindStringSubmatchIndex(line)
+		if m != nil && line[m[2]:m[3]] == "ERRORNEXT" {
+			errorNextTargets[i+2] = true
+		}
+	}
+
+	for i, line := range lines {
+		lineNo := i + 1
+		m := errorCommentRe.FindStringSubmatchIndex(line)
+		if m != nil && line[m[2]:m[3]] == "ERRORNEXT" {
+			continue
+		}
+
+		code := line
+		if m != nil {
+			code = strings.TrimRight(line[:m[0]], " \t")
+		}
+
+		msgs, ok := msgsByLine[lineNo]
+		if !ok || errorNextTargets[lineNo] {
+			lines[i] = code
+			continue
+		}
+
+		quoted := make([]string, len(msgs))
+		for j, msg := range msgs {
+			quoted[j] = `"` + strings.ReplaceAll(regexp.QuoteMeta(msg), `"`, `\"`) + `"`
+		}
+
+		if code == "" {
+			lines[i] = fmt.Sprintf("// ERROR %s", strings.Join(quoted, " "))
+		} else {
+			lines[i] = fmt.Sprintf("%s // ERROR %s", code, strings.Join(quoted, " "))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
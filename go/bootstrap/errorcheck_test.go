@@ -0,0 +1,74 @@
+package bootstrap
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseErrorAnnotations(t *testing.T) {
+	source := "line 1\n" +
+		"bad syntax here // ERROR \"unexpected token\"\n" +
+		"// ERRORNEXT \"undefined: x\"\n" +
+		"x + 1\n" +
+		"also bad // ERROR \"unexpected token\" \"(\"\n"
+
+	got := parseErrorAnnotations(source)
+	if len(got) != 3 {
+		t.Fatalf("len(annotations) = %d, want 3", len(got))
+	}
+
+	if got[0].Line != 2 || got[0].Raw[0] != "unexpected token" {
+		t.Errorf("annotations[0] = %+v", got[0])
+	}
+	if got[1].Line != 4 { // ERRORNEXT attaches to the following line
+		t.Errorf("annotations[1].Line = %d, want 4", got[1].Line)
+	}
+	if want := []string{"unexpected token"}; !reflect.DeepEqual(got[2].Raw, want) {
+		t.Errorf("annotations[2].Raw = %v, want %v", got[2].Raw, want)
+	}
+	if want := []string{"("}; !reflect.DeepEqual(got[2].Invalid, want) { // "(" doesn't compile as a regexp
+		t.Errorf("annotations[2].Invalid = %v, want %v", got[2].Invalid, want)
+	}
+}
+
+func TestParseErrorAnnotationsInvalidPattern(t *testing.T) {
+	source := "bad // ERROR \"unexpected token\" \"(unterminated\"\n"
+
+	got := parseErrorAnnotations(source)
+	if len(got) != 1 {
+		t.Fatalf("len(annotations) = %d, want 1", len(got))
+	}
+
+	ann := got[0]
+	if len(ann.Patterns) != 1 || len(ann.Raw) != 1 || ann.Raw[0] != "unexpected token" {
+		t.Errorf("Patterns/Raw desynced after a bad regex: %+v", ann)
+	}
+	if want := []string{"(unterminated"}; !reflect.DeepEqual(ann.Invalid, want) {
+		t.Errorf("Invalid = %v, want %v", ann.Invalid, want)
+	}
+}
+
+func TestRewriteErrorAnnotationsSkipsErrorNextTarget(t *testing.T) {
+	source := "// ERRORNEXT \"undefined: x\"\n" +
+		"x + 1\n"
+	diagnostics := []diagnostic{{File: "t.bit", Line: 2, Col: 1, Msg: "undefined: x"}}
+
+	got := rewriteErrorAnnotations(source, diagnostics)
+	if strings.Contains(got, "x + 1 // ERROR") {
+		t.Errorf("rewriteErrorAnnotations() duplicated an annotation already covered by ERRORNEXT: %q", got)
+	}
+	if !strings.Contains(got, "ERRORNEXT") {
+		t.Errorf("rewriteErrorAnnotations() dropped the ERRORNEXT annotation: %q", got)
+	}
+}
+
+func TestRewriteErrorAnnotationsInsertsNewAnnotation(t *testing.T) {
+	source := "bad syntax\n"
+	diagnostics := []diagnostic{{File: "t.bit", Line: 1, Col: 1, Msg: "unexpected token"}}
+
+	got := rewriteErrorAnnotations(source, diagnostics)
+	if !strings.Contains(got, `bad syntax // ERROR "unexpected token"`) {
+		t.Errorf("rewriteErrorAnnotations() = %q, want it to contain the new annotation", got)
+	}
+}
@@ -0,0 +1,58 @@
+package bootstrap
+
+import (
+	"flag"
+	"os"
+	"time"
+)
+
+// Reporter receives script test lifecycle events as the suite runs, so
+// tooling other than a terminal (CI dashboards, test UIs) can consume
+// results without scraping human-formatted text.
+type Reporter interface {
+	TestStarted(test *ScriptTest)
+	TestFinished(test *ScriptTest)
+	SuiteFinished(summary Summary)
+}
+
+// Summary is the aggregate result of a suite run, passed to
+// Reporter.SuiteFinished.
+type Summary struct {
+	Total   int
+	Passed  int
+	Failed  int
+	Skipped int
+	Elapsed time.Duration
+}
+
+// Summarize reduces a batch of results into a Summary.
+func Summarize(results []ScriptTest, elapsed time.Duration) Summary {
+	summary := Summary{Total: len(results), Elapsed: elapsed}
+	for _, test := range results {
+		switch {
+		case test.Skipped:
+			summary.Skipped++
+		case test.Success:
+			summary.Passed++
+		default:
+			summary.Failed++
+		}
+	}
+	return summary
+}
+
+// ActiveReporter is the Reporter used by RunScriptTest. RunAllScriptTests
+// sets it from RunOptions.Reporter, defaulting to the pretty printer.
+var ActiveReporter Reporter = NewTextReporter()
+
+var flagReport = flag.String("report", "text", "result reporter to use: text or json")
+
+// ReporterFromFlag returns the Reporter selected by the -report flag.
+func ReporterFromFlag() Reporter {
+	switch *flagReport {
+	case "json":
+		return NewJSONReporter(os.Stdout)
+	default:
+		return NewTextReporter()
+	}
+}
@@ -0,0 +1,83 @@
+package bootstrap
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// JSONReporter writes one JSON object per test, plus a final summary line,
+// so CI dashboards can consume results as structured data instead of
+// scraping the pretty terminal output. Selected with -report=json.
+type JSONReporter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func NewJSONReporter(w io.Writer) *JSONReporter {
+	return &JSONReporter{enc: json.NewEncoder(w)}
+}
+
+type jsonTestResult struct {
+	Name       string   `json:"name"`
+	File       string   `json:"file"`
+	Skipped    bool     `json:"skipped"`
+	Success    bool     `json:"success"`
+	Error      string   `json:"error,omitempty"`
+	ExitCode   int      `json:"exit_code"`
+	DurationMs int64    `json:"duration_ms"`
+	StdOut     string   `json:"stdout"`
+	StdErr     string   `json:"stderr"`
+	Expected   []string `json:"expected,omitempty"`
+	Actual     []string `json:"actual,omitempty"`
+	Diff       []string `json:"diff,omitempty"`
+}
+
+type jsonSuiteSummary struct {
+	Total     int   `json:"total"`
+	Passed    int   `json:"passed"`
+	Failed    int   `json:"failed"`
+	Skipped   int   `json:"skipped"`
+	ElapsedMs int64 `json:"elapsed_ms"`
+}
+
+func (r *JSONReporter) TestStarted(test *ScriptTest) {
+	// The JSON stream is one record per finished test; nothing to emit yet.
+}
+
+func (r *JSONReporter) TestFinished(test *ScriptTest) {
+	result := jsonTestResult{
+		Name:       test.Name,
+		File:       test.File,
+		Skipped:    test.Skipped,
+		Success:    test.Success,
+		ExitCode:   test.ExitCode,
+		DurationMs: test.Duration.Milliseconds(),
+		StdOut:     test.StdOut,
+		StdErr:     test.StdErr,
+		Expected:   test.ExpectOutput,
+		Actual:     test.ActualOutput,
+	}
+	if test.Error != nil {
+		result.Error = test.Error.Error()
+	}
+	if !test.Success && !test.Skipped {
+		result.Diff = diffLines(test)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_ = r.enc.Encode(result)
+}
+
+func (r *JSONReporter) SuiteFinished(summary Summary) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_ = r.enc.Encode(jsonSuiteSummary{
+		Total:     summary.Total,
+		Passed:    summary.Passed,
+		Failed:    summary.Failed,
+		Skipped:   summary.Skipped,
+		ElapsedMs: summary.Elapsed.Milliseconds(),
+	})
+}
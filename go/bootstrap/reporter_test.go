@@ -0,0 +1,25 @@
+package bootstrap
+
+import "testing"
+
+func TestSummarize(t *testing.T) {
+	results := []ScriptTest{
+		{Success: true},
+		{Success: true},
+		{Skipped: true},
+		{Success: false},
+	}
+
+	got := Summarize(results, 0)
+	want := Summary{Total: 4, Passed: 2, Skipped: 1, Failed: 1}
+	if got.Total != want.Total || got.Passed != want.Passed || got.Skipped != want.Skipped || got.Failed != want.Failed {
+		t.Errorf("Summarize() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSummarizeEmpty(t *testing.T) {
+	got := Summarize(nil, 0)
+	if got.Total != 0 || got.Passed != 0 || got.Failed != 0 || got.Skipped != 0 {
+		t.Errorf("Summarize(nil) = %+v, want all zero", got)
+	}
+}
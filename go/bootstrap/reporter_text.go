@@ -0,0 +1,131 @@
+package bootstrap
+
+import (
+	"fmt"
+	"sync"
+
+	"axlab.dev/bit/tester"
+	"axlab.dev/bit/util"
+)
+
+// TextReporter is the original human-formatted pretty printer: a banner per
+// test followed by PASS!/FAIL!/SKIP and, on failure, a diff of actual vs
+// expected output. It buffers each test's block between TestStarted and
+// TestFinished so concurrent workers (see RunAllScriptTests) can't
+// interleave their banners and diffs.
+type TextReporter struct {
+	mu      sync.Mutex
+	pending map[*ScriptTest]string
+}
+
+func NewTextReporter() *TextReporter {
+	return &TextReporter{pending: map[*ScriptTest]string{}}
+}
+
+func (r *TextReporter) TestStarted(test *ScriptTest) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending[test] = fmt.Sprintf("\n>>> [TEST] %s...", test.Name)
+}
+
+func (r *TextReporter) TestFinished(test *ScriptTest) {
+	r.mu.Lock()
+	block := r.pending[test]
+	delete(r.pending, test)
+	r.mu.Unlock()
+
+	block += r.resultLine(test)
+	if !test.Success && !test.Skipped {
+		block += r.detailsBlock(test)
+	}
+
+	r.mu.Lock()
+	fmt.Print(block)
+	r.mu.Unlock()
+}
+
+func (r *TextReporter) SuiteFinished(summary Summary) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fmt.Printf("\n>>> %d passed, %d failed, %d skipped (%v)\n",
+		summary.Passed, summary.Failed, summary.Skipped, summary.Elapsed)
+}
+
+func (r *TextReporter) resultLine(test *ScriptTest) string {
+	switch {
+	case test.Skipped:
+		if test.SkipReason != "" {
+			return fmt.Sprintf("SKIP (%s)\n", test.SkipReason)
+		}
+		return "SKIP\n"
+	case test.Success && test.Updated:
+		return "PASS! (updated)\n"
+	case test.Success && test.generateOutput != "":
+		return "PASS! (new)\n"
+	case test.Success:
+		return "PASS!\n"
+	case test.Error != nil && test.StdErr == "":
+		return fmt.Sprintf("\n... ERROR: %v\n", test.Error)
+	default:
+		return "FAIL!\n"
+	}
+}
+
+func (r *TextReporter) detailsBlock(test *ScriptTest) string {
+	if test.Error != nil && test.StdErr == "" {
+		return "" // already reported in resultLine
+	}
+
+	var out string
+	out += "\n==============================================\n"
+	out += test.Name
+	out += "\n==============================================\n\n"
+
+	if test.StdErr != "" && len(test.ActualOutput) == 0 {
+		out += "  - No output\n"
+	} else {
+		out += "  - Actual (-) to Expected (+) output diff:\n\n"
+		for _, line := range diffLines(test) {
+			out += "      " + line + "\n"
+		}
+	}
+
+	if test.StdErr != "" {
+		out += "\n  - Error output:\n\n"
+		for _, it := range util.TrimLines(util.Lines(test.StdErr)) {
+			out += "      " + it + "\n"
+		}
+	}
+
+	if test.ExitCode != 0 {
+		out += fmt.Sprintf("\n  - Exited with code %d\n", test.ExitCode)
+	}
+
+	out += "\n"
+	return out
+}
+
+// diffLines renders the actual-vs-expected output diff as display lines,
+// shared by the text reporter and the JSON reporter's `diff` field.
+func diffLines(test *ScriptTest) []string {
+	var lines []string
+	diff := tester.Compare(test.ActualOutput, test.ExpectOutput)
+	for _, it := range diff.Blocks() {
+		num := it.Dst
+		sign, text, pos := " ", test.ExpectOutput, it.Dst
+		if it.Kind > 0 {
+			sign = "+"
+		} else if it.Kind < 0 {
+			num = it.Src
+			sign, text, pos = "-", test.ActualOutput, it.Src
+		}
+		for i := 0; i < it.Len; i++ {
+			line := text[i+pos]
+			if line == "" {
+				line = "⏎"
+			}
+			lines = append(lines, fmt.Sprintf("%03d %s %s", num+i+1, sign, line))
+		}
+	}
+	return lines
+}
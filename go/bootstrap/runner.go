@@ -0,0 +1,95 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// bitBinary is the compiler binary script tests run against.
+var bitBinary = filepath.Join(CargoDir(), "target", "debug", "bit")
+
+// Runner invokes the bit compiler binary against a script test, either
+// running it to completion (ExecScript) or compiling it without executing
+// the result (CompileScript, BuildScript).
+type Runner struct {
+	verbose bool
+}
+
+// NewRunner returns a Runner that, when verbose, prints each command before
+// running it.
+func NewRunner(verbose bool) *Runner {
+	return &Runner{verbose: verbose}
+}
+
+// ExecScript runs file to completion, streaming its stdout/stderr to cb as
+// they arrive, and reports its exit code. If ctx expires first, the
+// script's whole process group is killed and ExecScript returns ctx.Err().
+func (r *Runner) ExecScript(ctx context.Context, file string, cb func(chunk string, isError bool)) (exitCode int, err error) {
+	cmd := exec.CommandContext(ctx, bitBinary, file)
+	cmd.Dir = filepath.Dir(file)
+	return r.run(ctx, cmd, cb)
+}
+
+// CompileScript runs file through the compiler front-end only, without
+// executing the resulting program.
+func (r *Runner) CompileScript(ctx context.Context, file string, cb func(chunk string, isError bool)) error {
+	cmd := exec.CommandContext(ctx, bitBinary, "compile", file)
+	cmd.Dir = filepath.Dir(file)
+	_, err := r.run(ctx, cmd, cb)
+	return err
+}
+
+// BuildScript runs file through code generation, without executing the
+// resulting program.
+func (r *Runner) BuildScript(ctx context.Context, file string, cb func(chunk string, isError bool)) error {
+	cmd := exec.CommandContext(ctx, bitBinary, "build", file)
+	cmd.Dir = filepath.Dir(file)
+	_, err := r.run(ctx, cmd, cb)
+	return err
+}
+
+// run executes cmd, streaming its stdout/stderr through cb, and returns its
+// exit code. cmd runs in its own process group so that, on ctx expiry, the
+// whole group (not just cmd itself) is killed instead of being left to
+// linger as a child of init. cmd.Dir is set by the caller rather than
+// chdir-ing the process, so concurrent runs don't race on the working
+// directory.
+func (r *Runner) run(ctx context.Context, cmd *exec.Cmd, cb func(chunk string, isError bool)) (exitCode int, err error) {
+	if r.verbose {
+		fmt.Println(cmd.Args)
+	}
+
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = 2 * time.Second
+
+	cmd.Stdout = &cbWriter{cb: cb, isError: false}
+	cmd.Stderr = &cbWriter{cb: cb, isError: true}
+
+	err = cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return -1, ctx.Err()
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return exitErr.ExitCode(), nil
+	}
+	return 0, err
+}
+
+// cbWriter adapts an io.Writer into the chunked output callback shared by
+// ExecScript, CompileScript, and BuildScript.
+type cbWriter struct {
+	cb      func(chunk string, isError bool)
+	isError bool
+}
+
+func (w *cbWriter) Write(p []byte) (int, error) {
+	w.cb(string(p), w.isError)
+	return len(p), nil
+}
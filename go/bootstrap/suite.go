@@ -0,0 +1,183 @@
+package bootstrap
+
+import (
+	"flag"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	flagWorkers = flag.Int("n", runtime.NumCPU(), "number of script tests to run in parallel")
+	flagShard   = flag.Int("shard", 0, "index of the shard to run (0-based)")
+	flagShards  = flag.Int("shards", 1, "number of shards to split the test suite into")
+	flagTags    = flag.String("tags", "", "comma-separated build tags checked against run:/skip: constraints")
+	flagTimeout = flag.Duration("timeout", DefaultTimeout, "per-test timeout before the script's process group is killed")
+
+	flagUpdateErrors = flag.Bool("update_errors", false, "on errorcheck mismatch, rewrite // ERROR annotations in place with the observed diagnostics")
+	flagUpdate       = flag.Bool("update", false, "on a failing run test, overwrite its .out/.out.json fixture with the observed output")
+)
+
+// RunOptions controls how RunAllScriptTests discovers and dispatches script
+// tests.
+type RunOptions struct {
+	// Workers is the number of script tests to run concurrently. Zero or
+	// negative defaults to runtime.NumCPU().
+	Workers int
+
+	// Shard and Shards restrict the suite to a subset of its tests, so it
+	// can be distributed across CI machines. Shards <= 1 runs every test.
+	Shard  int
+	Shards int
+
+	// Tags are checked against a script's `run:`/`skip:` build-tag-like
+	// constraint, in addition to runtime.GOOS.
+	Tags map[string]bool
+
+	// Timeout overrides DefaultTimeout for every test that doesn't set its
+	// own `// timeout:` directive. Zero leaves DefaultTimeout untouched.
+	Timeout time.Duration
+
+	// Reporter receives test lifecycle events. Defaults to ActiveReporter's
+	// current value (the pretty text printer) when nil.
+	Reporter Reporter
+
+	// Update makes a failing run test overwrite its `.out`/`.out.json`
+	// fixture with the observed output instead of failing.
+	Update bool
+}
+
+// RunOptionsFromFlags builds RunOptions from the -n/-shard/-shards/-tags/
+// -timeout/-report/-update command line flags. Call after flag.Parse().
+func RunOptionsFromFlags() RunOptions {
+	return RunOptions{
+		Workers:  *flagWorkers,
+		Shard:    *flagShard,
+		Shards:   *flagShards,
+		Tags:     parseTags(*flagTags),
+		Timeout:  *flagTimeout,
+		Reporter: ReporterFromFlag(),
+		Update:   *flagUpdate,
+	}
+}
+
+// parseTags splits a comma-separated tag list into a set.
+func parseTags(csv string) map[string]bool {
+	tags := map[string]bool{}
+	for _, tag := range strings.Split(csv, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags[tag] = true
+		}
+	}
+	return tags
+}
+
+// RunAllScriptTests discovers every script test under TestsDir, restricts
+// them to the requested shard, and runs them through a worker pool sized by
+// opts.Workers. Results are returned in discovery order. Test lifecycle
+// events go through opts.Reporter, which is responsible for keeping
+// concurrent workers from interleaving their output.
+func RunAllScriptTests(opts RunOptions) []ScriptTest {
+	if opts.Tags != nil {
+		Tags = opts.Tags
+	}
+	if opts.Timeout > 0 {
+		DefaultTimeout = opts.Timeout
+	}
+	if opts.Reporter != nil {
+		ActiveReporter = opts.Reporter
+	}
+	UpdateFixtures = opts.Update
+
+	start := time.Now()
+	names := discoverScriptTests()
+
+	shards := opts.Shards
+	if shards <= 0 {
+		shards = 1
+	}
+	if shards > 1 {
+		kept := names[:0]
+		for _, name := range names {
+			if shardFor(name, shards) == opts.Shard {
+				kept = append(kept, name)
+			}
+		}
+		names = kept
+	}
+
+	if len(names) == 0 {
+		ActiveReporter.SuiteFinished(Summarize(nil, time.Since(start)))
+		return nil
+	}
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(names) {
+		workers = len(names)
+	}
+
+	results := make([]ScriptTest, len(names))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				results[idx] = RunScriptTest(names[idx])
+			}
+		}()
+	}
+
+	for idx := range names {
+		jobs <- idx
+	}
+	close(jobs)
+	wg.Wait()
+
+	ActiveReporter.SuiteFinished(Summarize(results, time.Since(start)))
+	return results
+}
+
+// discoverScriptTests walks TestsDir and returns the path of every script
+// test relative to it, sorted for deterministic sharding.
+func discoverScriptTests() []string {
+	root := TestsDir()
+
+	var names []string
+	_ = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		if strings.HasSuffix(path, ".out") || strings.HasSuffix(path, ".out.json") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil
+		}
+		names = append(names, rel)
+		return nil
+	})
+
+	sort.Strings(names)
+	return names
+}
+
+// shardFor hashes a test's relative path with FNV-1a and maps it onto one
+// of the given shards.
+func shardFor(name string, shards int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	return int(h.Sum32() % uint32(shards))
+}
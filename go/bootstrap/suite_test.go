@@ -0,0 +1,40 @@
+package bootstrap
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestShardForIsDeterministic(t *testing.T) {
+	names := []string{"a/b.bit", "c/d.bit", "e/f.bit", "g/h.bit"}
+	for _, name := range names {
+		first := shardFor(name, 8)
+		for i := 0; i < 10; i++ {
+			if got := shardFor(name, 8); got != first {
+				t.Fatalf("shardFor(%q, 8) = %d, want %d (not deterministic)", name, got, first)
+			}
+		}
+	}
+}
+
+func TestShardForStaysInRange(t *testing.T) {
+	for shards := 1; shards <= 16; shards++ {
+		for i := 0; i < 100; i++ {
+			name := string(rune('a' + i%26))
+			if got := shardFor(name, shards); got < 0 || got >= shards {
+				t.Fatalf("shardFor(%q, %d) = %d, out of range", name, shards, got)
+			}
+		}
+	}
+}
+
+func TestShardForSpreadsAcrossShards(t *testing.T) {
+	const shards = 4
+	seen := map[int]bool{}
+	for i := 0; i < 1000; i++ {
+		seen[shardFor(fmt.Sprintf("pkg/test_%d.bit", i), shards)] = true
+	}
+	if len(seen) != shards {
+		t.Errorf("shardFor only used %d of %d shards: %v", len(seen), shards, seen)
+	}
+}
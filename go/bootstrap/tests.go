@@ -1,17 +1,50 @@
 package bootstrap
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
+	"strings"
+	"time"
 
-	"axlab.dev/bit/tester"
 	"axlab.dev/bit/util"
 )
 
+// DefaultTimeout is the per-test timeout applied when a script doesn't
+// override it with a `// timeout:30s` directive. RunAllScriptTests sets
+// this from the -timeout flag.
+var DefaultTimeout = 60 * time.Second
+
+// UpdateFixtures makes a failing ActionRun test overwrite its `.out`/
+// `.out.json` expectation with the observed output instead of failing, in
+// the format that fixture already has on disk. RunAllScriptTests sets this
+// from the -update flag.
+var UpdateFixtures = false
+
 func RunScriptTest(scriptFileName string) (out ScriptTest) {
+	start := time.Now()
+
 	out.File = filepath.Join(TestsDir(), scriptFileName)
 	out.Name = scriptFileName
-	out.outputStartBanner()
+	ActiveReporter.TestStarted(&out)
+	defer func() {
+		out.Duration = time.Since(start)
+		ActiveReporter.TestFinished(&out)
+	}()
+
+	out.Directive = parseDirective(util.ReadText(out.File))
+
+	if out.Directive.Constraint != "" && !directiveApplies(out.Directive.Constraint, Tags) {
+		out.Skipped = true
+		out.SkipReason = fmt.Sprintf("%s not satisfied", out.Directive.Constraint)
+		return
+	}
+
+	if out.Directive.Action == ActionSkip {
+		out.Skipped = true
+		out.SkipReason = out.Directive.Reason
+		return
+	}
 
 	outFile := out.File + ".out"
 	outJson := outFile + ".json"
@@ -19,38 +52,57 @@ func RunScriptTest(scriptFileName string) (out ScriptTest) {
 	outputText := util.ReadText(outFile)
 	outputJson := util.ReadJson(outJson, nil)
 
-	if outputText == "" && outputJson == nil {
+	switch {
+	case outputText == "" && outputJson == nil:
 		out.generateOutput = outFile
-	}
-
-	if outputText != "" && outputJson != nil {
+		if UpdateFixtures && out.Directive.Format == "json" {
+			out.generateOutput = outJson
+		}
+	case outputText != "" && outputJson != nil:
 		out.Error = fmt.Errorf("found both a text and JSON output")
 		return
+	case outputJson != nil:
+		out.Expected = outputJson
+		if UpdateFixtures {
+			out.updateFile = outJson
+		}
+	default:
+		out.Expected = outputText
+		if UpdateFixtures {
+			out.updateFile = outFile
+		}
 	}
 
-	out.Expected = outputJson
-	if outputText != "" {
-		out.Expected = outputText
+	out.Timeout = DefaultTimeout
+	if out.Directive.Timeout > 0 {
+		out.Timeout = out.Directive.Timeout
 	}
 
-	out.Directory = filepath.Dir(out.File)
+	// Runner kills the script's process group once ctx expires, so a runaway
+	// script can't hang the suite.
+	ctx, cancel := context.WithTimeout(context.Background(), out.Timeout)
+	defer cancel()
+
+	cb := func(output string, isError bool) {
+		if isError {
+			out.StdErr += output
+		} else {
+			out.StdOut += output
+		}
+	}
 
-	execOK := ExecInDir("test script", out.Directory, func() bool {
-		runner := NewRunner(false)
-		out.ExitCode, out.Error = runner.ExecScript(out.File,
-			func(output string, isError bool) {
-				if isError {
-					out.StdErr += output
-				} else {
-					out.StdOut += output
-				}
-			},
-		)
-		return true
-	})
+	runner := NewRunner(false)
+	switch out.Directive.Action {
+	case ActionCompile, ActionErrorCheck:
+		out.Error = runner.CompileScript(ctx, out.File, cb)
+	case ActionBuild:
+		out.Error = runner.BuildScript(ctx, out.File, cb)
+	default: // ActionRun
+		out.ExitCode, out.Error = runner.ExecScript(ctx, out.File, cb)
+	}
 
-	if !execOK && out.Error == nil {
-		out.Error = fmt.Errorf("execute in script dir failed")
+	if ctx.Err() == context.DeadlineExceeded {
+		out.Error = fmt.Errorf("timed out after %v", out.Timeout)
 	}
 
 	out.CheckResult()
@@ -58,13 +110,16 @@ func RunScriptTest(scriptFileName string) (out ScriptTest) {
 }
 
 type ScriptTest struct {
-	Name    string
-	File    string
-	Error   error
-	Success bool
-	Skipped bool
+	Name       string
+	File       string
+	Error      error
+	Success    bool
+	Skipped    bool
+	SkipReason string
 
-	Directory string
+	Directive Directive
+	Timeout   time.Duration
+	Duration  time.Duration
 
 	Expected any
 	StdOut   string
@@ -72,24 +127,59 @@ type ScriptTest struct {
 	ExitCode int
 
 	generateOutput string
+	updateFile     string
+	Updated        bool
 
 	ExpectOutput []string
 	ActualOutput []string
 }
 
+// CheckResult validates the outcome of the test's execution against what
+// its Directive expects, setting Success and Error. Reporting the result
+// is the Reporter's job, not CheckResult's.
 func (test *ScriptTest) CheckResult() {
-	if test.StdErr != "" {
+	switch test.Directive.Action {
+	case ActionCompile, ActionBuild:
+		test.checkCompileResult()
+	case ActionErrorCheck:
+		test.checkErrorCheckResult()
+	default:
+		test.checkOutputResult()
+	}
+}
+
+// checkCompileResult validates the compile/build-only actions, which only
+// expect the frontend to succeed without producing output.
+func (test *ScriptTest) checkCompileResult() {
+	if test.Error == nil && test.StdErr != "" {
 		test.Error = fmt.Errorf("test generated error output")
 	}
-	if test.ExitCode != 0 {
+	if test.Error == nil && test.StdOut != "" {
+		test.Error = fmt.Errorf("test generated output")
+	}
+	test.Success = test.Error == nil
+}
+
+// checkOutputResult is the original ActionRun behavior: execute the script
+// and diff its stdout against the `.out`/`.out.json` fixture.
+func (test *ScriptTest) checkOutputResult() {
+	if test.Error == nil && test.StdErr != "" {
+		test.Error = fmt.Errorf("test generated error output")
+	}
+	if test.Error == nil && test.ExitCode != 0 {
 		test.Error = fmt.Errorf("test exited with code %d", test.ExitCode)
 	}
 
 	actualLines := util.Lines(test.StdOut)
 	if test.generateOutput != "" {
-		util.WriteText(test.generateOutput, test.StdOut)
-		test.output("PASS! (new)\n")
-		test.Success = true
+		if test.Error == nil {
+			if strings.HasSuffix(test.generateOutput, ".json") {
+				util.WriteJson(test.generateOutput, actualLines)
+			} else {
+				util.WriteText(test.generateOutput, test.StdOut)
+			}
+			test.Success = true
+		}
 		return
 	}
 
@@ -115,68 +205,16 @@ func (test *ScriptTest) CheckResult() {
 
 	test.ActualOutput = actualLines
 	test.ExpectOutput = expectLines
-
 	test.Success = success
-	if test.Success {
-		test.output("PASS!\n")
-	} else if test.Error != nil {
-		test.output("\n... ERROR: %v\n", test.Error)
-	} else {
-		test.output("FAIL!\n")
-	}
-}
 
-func (test ScriptTest) OutputDetails() {
-	if test.Success || test.Skipped || (test.Error != nil && test.StdErr == "") {
-		return // nothing to output or we already output
-	}
-
-	test.output("\n==============================================\n")
-	test.output("%s", test.Name)
-	test.output("\n==============================================\n\n")
-
-	if test.StdErr != "" && len(test.ActualOutput) == 0 {
-		fmt.Println("  - No output")
-	} else {
-		diff := tester.Compare(test.ActualOutput, test.ExpectOutput)
-		test.output("  - Actual (-) to Expected (+) output diff:\n\n")
-		for _, it := range diff.Blocks() {
-			num := it.Dst
-			sign, text, pos := " ", test.ExpectOutput, it.Dst
-			if it.Kind > 0 {
-				sign = "+"
-			} else if it.Kind < 0 {
-				num = it.Src
-				sign, text, pos = "-", test.ActualOutput, it.Src
-			}
-			for i := 0; i < it.Len; i++ {
-				line := text[i+pos]
-				if line == "" {
-					line = "⏎"
-				}
-				test.output("      %03d %s %s\n", num+i+1, sign, line)
-			}
-		}
-	}
-
-	if test.StdErr != "" {
-		test.output("\n  - Error output:\n\n")
-		for _, it := range util.TrimLines(util.Lines(test.StdErr)) {
-			test.output("      %s\n", it)
+	if test.Error == nil && !test.Success && test.updateFile != "" {
+		if strings.HasSuffix(test.updateFile, ".json") {
+			util.WriteJson(test.updateFile, actualLines)
+		} else {
+			util.WriteText(test.updateFile, test.StdOut)
 		}
+		test.Error = nil
+		test.Success = true
+		test.Updated = true
 	}
-
-	if test.ExitCode != 0 {
-		test.output("\n  - Exited with code %d\n", test.ExitCode)
-	}
-
-	test.output("\n")
-}
-
-func (test ScriptTest) outputStartBanner() {
-	test.output("\n>>> [TEST] %s...", test.Name)
-}
-
-func (test ScriptTest) output(msg string, args ...any) {
-	fmt.Printf(msg, args...)
 }
@@ -0,0 +1,52 @@
+package bootstrap
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckOutputResultSkipsGenerateOnError(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "t.bit.out")
+
+	test := ScriptTest{
+		generateOutput: out,
+		StdOut:         "partial output before timeout\n",
+		Error:          fmt.Errorf("timed out after 1s"),
+	}
+	test.checkOutputResult()
+
+	if test.Success {
+		t.Errorf("checkOutputResult() set Success on an errored run")
+	}
+	if _, err := os.Stat(out); err == nil {
+		t.Errorf("checkOutputResult() wrote a fixture for an errored run")
+	}
+}
+
+func TestCheckOutputResultSkipsUpdateOnError(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "t.bit.out")
+	if err := os.WriteFile(out, []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	test := ScriptTest{
+		updateFile: out,
+		Expected:   "old\n",
+		StdOut:     "new\n",
+		Error:      fmt.Errorf("timed out after 1s"),
+	}
+	test.checkOutputResult()
+
+	if test.Success || test.Updated {
+		t.Errorf("checkOutputResult() updated the fixture for an errored run")
+	}
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "old\n" {
+		t.Errorf("checkOutputResult() overwrote the fixture: %q", got)
+	}
+}